@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"time"
+)
+
+// GuardrailError marks an action that a safety guardrail blocked rather than an execution
+// failure, so callers can log action=blocked reason=... instead of treating it like a
+// database error (no reconnect attempt).
+type GuardrailError struct {
+	Reason string
+}
+
+func (e *GuardrailError) Error() string {
+	return fmt.Sprintf("blocked by guardrail: %s", e.Reason)
+}
+
+var (
+	errRateLimited     = &GuardrailError{Reason: "rate_limit"}
+	errErrnoNotAllowed = &GuardrailError{Reason: "errno_not_allowed"}
+)
+
+// errnoAllowed reports whether errno may trigger a guarded action, per cfg.ErrnoAllowlist.
+// An empty allowlist permits every errno.
+func errnoAllowed(cfg *GlobalConfig, errno int) bool {
+	if len(cfg.ErrnoAllowlist) == 0 {
+		return true
+	}
+	for _, allowed := range cfg.ErrnoAllowlist {
+		if allowed == errno {
+			return true
+		}
+	}
+	return false
+}
+
+// guardMutatingAction is the single gate every action that can mutate replication state or
+// shell out goes through, whether it's one of the built-ins (skip/optimize/reset_pos) or a
+// rule's exec_sql/shell: it enforces cfg.ErrnoAllowlist, then a rate limit appropriate to
+// the action. reset_pos consults its own per-day budget; everything else -- including
+// exec_sql and shell, which could otherwise recreate skip/reset_pos behavior and bypass
+// their budgets entirely -- shares the skip-rate budget, since that's the only per-minute
+// guard this config exposes and shell in particular can refire every poll cycle otherwise.
+func guardMutatingAction(cfg *GlobalConfig, tracker *LagTracker, status *SlaveStatus, action string, now time.Time) error {
+	if !errnoAllowed(cfg, status.Errno) {
+		return errErrnoNotAllowed
+	}
+	var ok bool
+	if action == "reset_pos" {
+		ok = withinResetBudget(tracker, cfg, now)
+	} else {
+		ok = withinSkipBudget(tracker, cfg, now)
+	}
+	if !ok {
+		return errRateLimited
+	}
+	return nil
+}
+
+// withinSkipBudget trims tracker.SkipTimestamps to the last hour and reports whether one
+// more skip action at now would stay within cfg.MaxSkipsPerMinute/MaxSkipsPerHour (0 means
+// unlimited). On success it records now so subsequent calls see it.
+func withinSkipBudget(tracker *LagTracker, cfg *GlobalConfig, now time.Time) bool {
+	tracker.SkipTimestamps = trimBefore(tracker.SkipTimestamps, now.Add(-time.Hour))
+
+	if cfg.MaxSkipsPerMinute > 0 && countSince(tracker.SkipTimestamps, now.Add(-time.Minute)) >= cfg.MaxSkipsPerMinute {
+		return false
+	}
+	if cfg.MaxSkipsPerHour > 0 && len(tracker.SkipTimestamps) >= cfg.MaxSkipsPerHour {
+		return false
+	}
+
+	tracker.SkipTimestamps = append(tracker.SkipTimestamps, now)
+	return true
+}
+
+// withinResetBudget is withinSkipBudget's analog for cfg.MaxResetsPerDay.
+func withinResetBudget(tracker *LagTracker, cfg *GlobalConfig, now time.Time) bool {
+	tracker.ResetTimestamps = trimBefore(tracker.ResetTimestamps, now.Add(-24*time.Hour))
+
+	if cfg.MaxResetsPerDay > 0 && len(tracker.ResetTimestamps) >= cfg.MaxResetsPerDay {
+		return false
+	}
+
+	tracker.ResetTimestamps = append(tracker.ResetTimestamps, now)
+	return true
+}
+
+// trimBefore drops every timestamp at or before cutoff, preserving order.
+func trimBefore(timestamps []time.Time, cutoff time.Time) []time.Time {
+	kept := timestamps[:0]
+	for _, t := range timestamps {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+func countSince(timestamps []time.Time, cutoff time.Time) int {
+	count := 0
+	for _, t := range timestamps {
+		if t.After(cutoff) {
+			count++
+		}
+	}
+	return count
+}
+
+// runOnBlockCommand fires cfg.OnBlockCommand, if configured, whenever a guardrail blocks an
+// action -- e.g. to page on-call via a webhook/pagerduty CLI.
+func runOnBlockCommand(cfg *GlobalConfig, target Target, reason string, logger *slog.Logger) {
+	if cfg.OnBlockCommand == "" {
+		return
+	}
+	cmd := exec.Command("sh", "-c", cfg.OnBlockCommand)
+	cmd.Env = append(cmd.Env, "TARGET="+target.Name, "REASON="+reason)
+	if err := cmd.Run(); err != nil {
+		logger.Error("on_block_command failed", "event", "on_block_command_failed", "error", err)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestErrnoAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist []int
+		errno     int
+		want      bool
+	}{
+		{"empty allowlist permits everything", nil, 1062, true},
+		{"errno in allowlist", []int{1062, 1146}, 1062, true},
+		{"errno not in allowlist", []int{1062, 1146}, 1942, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &GlobalConfig{ErrnoAllowlist: tt.allowlist}
+			if got := errnoAllowed(cfg, tt.errno); got != tt.want {
+				t.Errorf("errnoAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithinSkipBudgetPerMinute(t *testing.T) {
+	cfg := &GlobalConfig{MaxSkipsPerMinute: 2}
+	tracker := &LagTracker{}
+	now := time.Now()
+
+	if !withinSkipBudget(tracker, cfg, now) {
+		t.Fatal("1st skip should be within budget")
+	}
+	if !withinSkipBudget(tracker, cfg, now.Add(time.Second)) {
+		t.Fatal("2nd skip should be within budget")
+	}
+	if withinSkipBudget(tracker, cfg, now.Add(2*time.Second)) {
+		t.Fatal("3rd skip within the same minute should be blocked")
+	}
+	if !withinSkipBudget(tracker, cfg, now.Add(90*time.Second)) {
+		t.Fatal("skip outside the 1-minute window should be allowed again")
+	}
+}
+
+func TestWithinSkipBudgetPerHour(t *testing.T) {
+	cfg := &GlobalConfig{MaxSkipsPerHour: 1}
+	tracker := &LagTracker{}
+	now := time.Now()
+
+	if !withinSkipBudget(tracker, cfg, now) {
+		t.Fatal("1st skip should be within budget")
+	}
+	// Well outside the per-minute window, but still within the hour.
+	if withinSkipBudget(tracker, cfg, now.Add(10*time.Minute)) {
+		t.Fatal("2nd skip within the same hour should be blocked by the per-hour cap")
+	}
+}
+
+func TestWithinSkipBudgetUnlimited(t *testing.T) {
+	cfg := &GlobalConfig{} // MaxSkipsPerMinute/Hour both 0 -> unlimited
+	tracker := &LagTracker{}
+	now := time.Now()
+
+	for i := 0; i < 100; i++ {
+		if !withinSkipBudget(tracker, cfg, now.Add(time.Duration(i)*time.Millisecond)) {
+			t.Fatalf("skip %d should be unlimited when no budget is configured", i)
+		}
+	}
+}
+
+func TestWithinResetBudgetPerDay(t *testing.T) {
+	cfg := &GlobalConfig{MaxResetsPerDay: 1}
+	tracker := &LagTracker{}
+	now := time.Now()
+
+	if !withinResetBudget(tracker, cfg, now) {
+		t.Fatal("1st reset should be within budget")
+	}
+	if withinResetBudget(tracker, cfg, now.Add(time.Hour)) {
+		t.Fatal("2nd reset within the same day should be blocked")
+	}
+	if !withinResetBudget(tracker, cfg, now.Add(25*time.Hour)) {
+		t.Fatal("reset outside the 24h window should be allowed again")
+	}
+}
+
+func TestGuardMutatingAction(t *testing.T) {
+	now := time.Now()
+
+	t.Run("blocks disallowed errno before consulting any budget", func(t *testing.T) {
+		cfg := &GlobalConfig{ErrnoAllowlist: []int{1146}}
+		tracker := &LagTracker{}
+		err := guardMutatingAction(cfg, tracker, &SlaveStatus{Errno: 1062}, "skip", now)
+		if err != errErrnoNotAllowed {
+			t.Errorf("guardMutatingAction() = %v, want errErrnoNotAllowed", err)
+		}
+	})
+
+	t.Run("exec_sql and shell share the skip budget", func(t *testing.T) {
+		cfg := &GlobalConfig{MaxSkipsPerMinute: 1}
+		tracker := &LagTracker{}
+		status := &SlaveStatus{Errno: 1062}
+
+		if err := guardMutatingAction(cfg, tracker, status, "exec_sql", now); err != nil {
+			t.Fatalf("1st exec_sql should be allowed, got %v", err)
+		}
+		if err := guardMutatingAction(cfg, tracker, status, "shell", now.Add(time.Second)); err != errRateLimited {
+			t.Errorf("shell should consume the same budget as exec_sql/skip, got %v", err)
+		}
+	})
+
+	t.Run("reset_pos uses the per-day budget, independent of skips", func(t *testing.T) {
+		cfg := &GlobalConfig{MaxSkipsPerMinute: 0, MaxResetsPerDay: 1}
+		tracker := &LagTracker{}
+		status := &SlaveStatus{Errno: 1062}
+
+		if err := guardMutatingAction(cfg, tracker, status, "reset_pos", now); err != nil {
+			t.Fatalf("1st reset_pos should be allowed, got %v", err)
+		}
+		if err := guardMutatingAction(cfg, tracker, status, "reset_pos", now.Add(time.Minute)); err != errRateLimited {
+			t.Errorf("2nd reset_pos same day should be rate limited, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalculateLagIndicatorFirstSample(t *testing.T) {
+	cfg := &GlobalConfig{EwmaHalfLifeSeconds: 60}
+
+	tests := []struct {
+		name   string
+		status *SlaveStatus
+		want   string
+	}{
+		{"caught up", &SlaveStatus{ReadMasterLogPos: 100, ExecMasterLogPos: 100}, "✓ (caught up)"},
+		{"behind", &SlaveStatus{ReadMasterLogPos: 500, ExecMasterLogPos: 100}, "lag: 400 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &LagTracker{LastCheck: time.Now()}
+			got := calculateLagIndicator(tracker, tt.status, cfg)
+			if got != tt.want {
+				t.Errorf("calculateLagIndicator() = %q, want %q", got, tt.want)
+			}
+			if tracker.LastReadPos != tt.status.ReadMasterLogPos || tracker.LastExecPos != tt.status.ExecMasterLogPos {
+				t.Errorf("tracker positions not primed from first sample: got read=%d exec=%d", tracker.LastReadPos, tracker.LastExecPos)
+			}
+		})
+	}
+}
+
+func TestCalculateLagIndicatorEWMA(t *testing.T) {
+	cfg := &GlobalConfig{EwmaHalfLifeSeconds: 60}
+	tracker := &LagTracker{LastCheck: time.Now().Add(-2 * time.Second)}
+
+	// Prime the tracker with a first sample. Positions must be non-zero, since
+	// LastReadPos == 0 is what calculateLagIndicator treats as "no prior sample yet".
+	calculateLagIndicator(tracker, &SlaveStatus{ReadMasterLogPos: 2000, ExecMasterLogPos: 1}, cfg)
+	tracker.LastCheck = time.Now().Add(-1 * time.Second)
+
+	// The SQL thread is applying twice as fast as the IO thread is reading, so we're
+	// catching up and should get a positive ETA.
+	indicator := calculateLagIndicator(tracker, &SlaveStatus{ReadMasterLogPos: 3000, ExecMasterLogPos: 2001}, cfg)
+
+	if tracker.ExecRateEWMA <= tracker.ReadRateEWMA {
+		t.Errorf("expected ExecRateEWMA > ReadRateEWMA once catching up, got exec=%f read=%f", tracker.ExecRateEWMA, tracker.ReadRateEWMA)
+	}
+	if tracker.ETASeconds <= 0 {
+		t.Errorf("expected a positive ETA while catching up, got %f", tracker.ETASeconds)
+	}
+	if !strings.Contains(indicator, "catching up") {
+		t.Errorf("indicator = %q, want it to mention catching up", indicator)
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{0, "0s"},
+		{30, "30s"},
+		{90, "1m30s"},
+	}
+	for _, tt := range tests {
+		if got := formatETA(tt.seconds); got != tt.want {
+			t.Errorf("formatETA(%v) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestParseGTIDSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[uint64]uint64
+	}{
+		{"empty", "", map[uint64]uint64{}},
+		{"single domain", "0-1-100", map[uint64]uint64{0: 100}},
+		{"multiple domains", "0-1-100,1-1-200", map[uint64]uint64{0: 100, 1: 200}},
+		{"keeps highest seq per domain", "0-1-100,0-2-150", map[uint64]uint64{0: 150}},
+		{"malformed entries ignored", "0-1-100,garbage,1-2", map[uint64]uint64{0: 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseGTIDSet(tt.input)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseGTIDSet(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+			for domain, seq := range tt.want {
+				if got[domain] != seq {
+					t.Errorf("parseGTIDSet(%q)[%d] = %d, want %d", tt.input, domain, got[domain], seq)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateGtidLagIndicator(t *testing.T) {
+	tracker := &LagTracker{}
+
+	caughtUp := calculateGtidLagIndicator(tracker, &SlaveStatus{GtidIOPos: "0-1-100", GtidSlavePos: "0-1-100"})
+	if caughtUp != "✓ (caught up, gtid)" {
+		t.Errorf("caught up indicator = %q", caughtUp)
+	}
+	if tracker.GtidDomainLag[0] != 0 {
+		t.Errorf("expected zero domain lag when caught up, got %d", tracker.GtidDomainLag[0])
+	}
+
+	behind := calculateGtidLagIndicator(tracker, &SlaveStatus{GtidIOPos: "0-1-150", GtidSlavePos: "0-1-100"})
+	if !strings.Contains(behind, "domain 0: 50") {
+		t.Errorf("behind indicator = %q, want it to mention domain 0 lag of 50", behind)
+	}
+	if tracker.GtidDomainLag[0] != 50 {
+		t.Errorf("tracker.GtidDomainLag[0] = %d, want 50", tracker.GtidDomainLag[0])
+	}
+}
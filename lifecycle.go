@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// runningMonitor tracks one active monitorTarget goroutine so it can be cancelled
+// individually (on reload, when its target section disappears) and have its config
+// swapped in place (on reload, when its target section is still present).
+type runningMonitor struct {
+	cancel context.CancelFunc
+	cfg    atomic.Pointer[GlobalConfig]
+}
+
+// superviseTargets starts one monitor goroutine per target, keeps that set in sync with
+// configFile whenever reload fires (SIGHUP), and drains every monitor within its
+// LameDuckSeconds window once ctx is cancelled (SIGINT/SIGTERM).
+func superviseTargets(ctx context.Context, configFile string, cfg *GlobalConfig, targets []Target, reload <-chan struct{}) {
+	var wg sync.WaitGroup
+	running := make(map[string]*runningMonitor, len(targets))
+	lameDuck := time.Duration(cfg.LameDuckSeconds) * time.Second
+
+	start := func(t Target, cfg *GlobalConfig) {
+		mctx, cancel := context.WithCancel(ctx)
+		rm := &runningMonitor{cancel: cancel}
+		rm.cfg.Store(cfg)
+		running[t.Name] = rm
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			monitorTarget(mctx, t, &rm.cfg)
+		}()
+	}
+
+	for _, t := range targets {
+		start(t, cfg)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			waitWithTimeout(&wg, lameDuck)
+			return
+
+		case <-reload:
+			newCfg, newTargets, err := loadConfig(configFile)
+			if err != nil {
+				log.Printf("Reload failed, keeping previous configuration: %v", err)
+				continue
+			}
+			lameDuck = time.Duration(newCfg.LameDuckSeconds) * time.Second
+
+			seen := make(map[string]bool, len(newTargets))
+			for _, t := range newTargets {
+				seen[t.Name] = true
+				if rm, ok := running[t.Name]; ok {
+					rm.cfg.Store(newCfg)
+				} else {
+					log.Printf("Reload: starting new target %q", t.Name)
+					start(t, newCfg)
+				}
+			}
+			for name, rm := range running {
+				if seen[name] {
+					continue
+				}
+				log.Printf("Reload: stopping removed target %q", name)
+				rm.cancel()
+				delete(running, name)
+				// monitorTarget removes its own metrics/health entry once it observes
+				// ctx.Done() -- doing it here instead would race the goroutine, which
+				// only checks ctx.Err() at loop boundaries and could still be mid-poll.
+			}
+			log.Printf("Reload complete: %d target(s) monitored", len(running))
+		}
+	}
+}
+
+// waitWithTimeout waits for wg, but gives up after timeout so shutdown doesn't hang
+// forever on a monitor stuck mid-query.
+func waitWithTimeout(wg *sync.WaitGroup, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("Lame-duck window (%s) expired with monitors still draining; exiting anyway", timeout)
+	}
+}
@@ -1,11 +1,21 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"fmt"
 	"log"
-	"sync"
+	"log/slog"
+	"math"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -19,6 +29,26 @@ type GlobalConfig struct {
 	MasterLogPos               int
 	MaxBackoffSeconds          int
 	BackoffSuccessCount        int
+	ListenAddr                 string
+	ReplicationMode            string  // filepos|gtid|auto
+	RecoveryGtid               string  // GTID position to recover to, or "current" for Gtid_IO_Pos
+	Rules                      []Rule  // Ordered error-handler rules, loaded from [rule:<name>] sections
+	DefaultAction              string  // Action for errnos matched by no rule: none|skip|optimize|reset_pos|stop_slave
+	LameDuckSeconds            int     // Max time to let in-flight monitors drain on SIGINT/SIGTERM
+	EwmaHalfLifeSeconds        float64 // Half-life for the read/exec rate EWMAs used to compute ETA
+	EtaBackoffThresholdSeconds float64 // ETA-to-caught-up above which polling backs off early
+	LogLevel                   string  // debug|info|warn|error
+	LogFormat                  string  // text|json
+	LogDir                     string  // Per-target rotating log files written here; empty disables file sink
+	LogMaxSizeMB               int     // Rotate a target's log file once it exceeds this size
+	LogMaxBackups              int     // Number of rotated files to keep per target
+	LogMaxAgeDays              int     // Days to retain rotated files
+	MaxSkipsPerMinute          int     // 0 means unlimited
+	MaxSkipsPerHour            int     // 0 means unlimited
+	MaxResetsPerDay            int     // 0 means unlimited
+	ErrnoAllowlist             []int   // If non-empty, skip/optimize/reset_pos only fire for these errnos
+	DryRun                     bool    // Log intended SQL for guarded actions instead of executing it
+	OnBlockCommand             string  // Shell command run (via sh -c) when a guardrail blocks an action
 }
 
 type Target struct {
@@ -34,6 +64,10 @@ type SlaveStatus struct {
 	ReadMasterLogPos    int64
 	ExecMasterLogPos    int64
 	SecondsBehindMaster int64
+	GtidIOPos           string // Gtid_IO_Pos: last GTID received by the IO thread
+	GtidSlavePos        string // Gtid_Slave_Pos: last GTID applied by the SQL thread
+	UsingGtid           string // Using_Gtid: No|Current_Pos|Slave_Pos
+	ErrorText           string // Last_SQL_Error, matched against rules' error_text_regex
 }
 
 type LagTracker struct {
@@ -43,10 +77,19 @@ type LagTracker struct {
 	ZeroErrCount       int
 	BackoffSeconds     int
 	LastSecondsBehind  int64
-	CurrentErrorCode   int       // Track current error being handled
-	ErrorCount         int       // Count of repeated errors
-	FirstErrorTime     time.Time // When the current error series started
-	LastLoggedErrorSeq int       // Track which error sequence was last logged
+	CurrentErrorCode   int                  // Track current error being handled
+	ErrorCount         int                  // Count of repeated errors
+	FirstErrorTime     time.Time            // When the current error series started
+	LastLoggedErrorSeq int                  // Track which error sequence was last logged
+	ReadRateBps        float64              // Last observed Read_Master_Log_Pos rate, bytes/sec
+	ExecRateBps        float64              // Last observed Exec_Master_Log_Pos rate, bytes/sec
+	GtidDomainLag      map[uint64]int64     // Per-domain (IO seq - SQL seq), when in GTID mode
+	RuleLastFired      map[string]time.Time // Last time each named rule fired, for cooldowns
+	ReadRateEWMA       float64              // EWMA of Read_Master_Log_Pos rate, bytes/sec
+	ExecRateEWMA       float64              // EWMA of Exec_Master_Log_Pos rate, bytes/sec
+	ETASeconds         float64              // Estimated seconds to catch up, 0 if not catching up
+	SkipTimestamps     []time.Time          // Sliding window of skip actions taken, for max_skips_per_minute/hour
+	ResetTimestamps    []time.Time          // Sliding window of reset actions taken, for max_resets_per_day
 }
 
 func loadConfig(filename string) (*GlobalConfig, []Target, error) {
@@ -63,6 +106,18 @@ func loadConfig(filename string) (*GlobalConfig, []Target, error) {
 		MasterLogPos:               4,
 		MaxBackoffSeconds:          15,
 		BackoffSuccessCount:        5,
+		ListenAddr:                 ":9104",
+		ReplicationMode:            "auto",
+		RecoveryGtid:               "current",
+		DefaultAction:              "none",
+		LameDuckSeconds:            10,
+		EwmaHalfLifeSeconds:        60,
+		EtaBackoffThresholdSeconds: 30,
+		LogLevel:                   "info",
+		LogFormat:                  "text",
+		LogMaxSizeMB:               100,
+		LogMaxBackups:              5,
+		LogMaxAgeDays:              28,
 	}
 
 	// Override with values from [defaults] section if present
@@ -86,12 +141,109 @@ func loadConfig(filename string) (*GlobalConfig, []Target, error) {
 		if defaults.HasKey("backoff_success_count") {
 			globalCfg.BackoffSuccessCount = defaults.Key("backoff_success_count").MustInt(5)
 		}
+		if defaults.HasKey("listen_addr") {
+			globalCfg.ListenAddr = defaults.Key("listen_addr").MustString(":9104")
+		}
+		if defaults.HasKey("replication_mode") {
+			globalCfg.ReplicationMode = defaults.Key("replication_mode").MustString("auto")
+		}
+		if defaults.HasKey("recovery_gtid") {
+			globalCfg.RecoveryGtid = defaults.Key("recovery_gtid").MustString("current")
+		}
+		if defaults.HasKey("default_action") {
+			globalCfg.DefaultAction = defaults.Key("default_action").MustString("none")
+		}
+		if defaults.HasKey("lame_duck_seconds") {
+			globalCfg.LameDuckSeconds = defaults.Key("lame_duck_seconds").MustInt(10)
+		}
+		if defaults.HasKey("ewma_half_life_seconds") {
+			globalCfg.EwmaHalfLifeSeconds = defaults.Key("ewma_half_life_seconds").MustFloat64(60)
+		}
+		if defaults.HasKey("eta_backoff_threshold_seconds") {
+			globalCfg.EtaBackoffThresholdSeconds = defaults.Key("eta_backoff_threshold_seconds").MustFloat64(30)
+		}
+		if defaults.HasKey("log_level") {
+			globalCfg.LogLevel = defaults.Key("log_level").MustString("info")
+		}
+		if defaults.HasKey("log_format") {
+			globalCfg.LogFormat = defaults.Key("log_format").MustString("text")
+		}
+		if defaults.HasKey("log_dir") {
+			globalCfg.LogDir = defaults.Key("log_dir").String()
+		}
+		if defaults.HasKey("log_max_size_mb") {
+			globalCfg.LogMaxSizeMB = defaults.Key("log_max_size_mb").MustInt(100)
+		}
+		if defaults.HasKey("log_max_backups") {
+			globalCfg.LogMaxBackups = defaults.Key("log_max_backups").MustInt(5)
+		}
+		if defaults.HasKey("log_max_age_days") {
+			globalCfg.LogMaxAgeDays = defaults.Key("log_max_age_days").MustInt(28)
+		}
+		if defaults.HasKey("max_skips_per_minute") {
+			globalCfg.MaxSkipsPerMinute = defaults.Key("max_skips_per_minute").MustInt(0)
+		}
+		if defaults.HasKey("max_skips_per_hour") {
+			globalCfg.MaxSkipsPerHour = defaults.Key("max_skips_per_hour").MustInt(0)
+		}
+		if defaults.HasKey("max_resets_per_day") {
+			globalCfg.MaxResetsPerDay = defaults.Key("max_resets_per_day").MustInt(0)
+		}
+		if defaults.HasKey("require_errno_allowlist") {
+			for _, field := range strings.Split(defaults.Key("require_errno_allowlist").String(), ",") {
+				field = strings.TrimSpace(field)
+				if field == "" {
+					continue
+				}
+				errno, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, nil, fmt.Errorf("invalid require_errno_allowlist entry %q: %w", field, err)
+				}
+				globalCfg.ErrnoAllowlist = append(globalCfg.ErrnoAllowlist, errno)
+			}
+		}
+		if defaults.HasKey("dry_run") {
+			globalCfg.DryRun = defaults.Key("dry_run").MustBool(false)
+		}
+		if defaults.HasKey("on_block_command") {
+			globalCfg.OnBlockCommand = defaults.Key("on_block_command").String()
+		}
+	}
+
+	switch globalCfg.ReplicationMode {
+	case "filepos", "gtid", "auto":
+	default:
+		return nil, nil, fmt.Errorf("invalid replication_mode %q: must be filepos, gtid, or auto", globalCfg.ReplicationMode)
+	}
+
+	switch globalCfg.LogFormat {
+	case "text", "json":
+	default:
+		return nil, nil, fmt.Errorf("invalid log_format %q: must be text or json", globalCfg.LogFormat)
+	}
+
+	switch strings.ToLower(globalCfg.LogLevel) {
+	case "debug", "info", "warn", "error":
+	default:
+		return nil, nil, fmt.Errorf("invalid log_level %q: must be debug, info, warn, or error", globalCfg.LogLevel)
 	}
 
+	switch globalCfg.DefaultAction {
+	case "none", "skip", "optimize", "reset_pos", "stop_slave":
+	default:
+		return nil, nil, fmt.Errorf("invalid default_action %q: must be none, skip, optimize, reset_pos, or stop_slave", globalCfg.DefaultAction)
+	}
+
+	rules, err := loadRules(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	globalCfg.Rules = rules
+
 	var targets []Target
 	for _, section := range cfg.Sections() {
-		// Skip the default section and defaults section
-		if section.Name() == "DEFAULT" || section.Name() == "defaults" {
+		// Skip the default section, the defaults section, and rule:* sections
+		if section.Name() == "DEFAULT" || section.Name() == "defaults" || strings.HasPrefix(section.Name(), "rule:") {
 			continue
 		}
 		targets = append(targets, Target{
@@ -123,22 +275,41 @@ func main() {
 
 	log.Printf("Loaded %d target(s) from config\n", len(targets))
 
-	// Start a goroutine for each target
-	var wg sync.WaitGroup
-	for _, target := range targets {
-		wg.Add(1)
-		go func(t Target) {
-			defer wg.Done()
-			monitorTarget(t, globalCfg)
-		}(target)
-	}
+	startMetricsServer(globalCfg.ListenAddr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reload := make(chan struct{}, 1)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for sig := range sigCh {
+			if sig == syscall.SIGHUP {
+				log.Printf("Received SIGHUP, reloading configuration from %s", *configFile)
+				select {
+				case reload <- struct{}{}:
+				default:
+				}
+				continue
+			}
+			log.Printf("Received %s, shutting down (lame-duck window %ds)", sig, globalCfg.LameDuckSeconds)
+			cancel()
+			return
+		}
+	}()
 
-	// Wait for all goroutines to finish (they shouldn't unless there's a fatal error)
-	wg.Wait()
+	// Start a goroutine for each target and keep the set in sync with the config file
+	superviseTargets(ctx, *configFile, globalCfg, targets, reload)
+
+	log.Printf("All monitors stopped, exiting")
 }
 
-func monitorTarget(target Target, cfg *GlobalConfig) {
-	logger := log.New(log.Writer(), fmt.Sprintf("[%s] ", target.Name), log.LstdFlags)
+func monitorTarget(ctx context.Context, target Target, cfgAtomic *atomic.Pointer[GlobalConfig]) {
+	initialCfg := cfgAtomic.Load()
+	logger, levelVar, closer := newTargetLogger(target.Name, initialCfg)
+	if closer != nil {
+		defer closer.Close()
+	}
 
 	// Create connection string with optimizations
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/?interpolateParams=true&timeout=10s&readTimeout=30s&writeTimeout=10s&multiStatements=true",
@@ -147,7 +318,8 @@ func monitorTarget(target Target, cfg *GlobalConfig) {
 	// Open database connection (reused throughout)
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		logger.Fatalf("Failed to connect to database: %v", err)
+		logger.Error("failed to connect to database", "event", "connect_failed", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close()
 
@@ -159,174 +331,258 @@ func monitorTarget(target Target, cfg *GlobalConfig) {
 
 	// Test connection
 	if err := db.Ping(); err != nil {
-		logger.Fatalf("Failed to ping database: %v", err)
+		logger.Error("failed to ping database", "event", "ping_failed", "error", err)
+		os.Exit(1)
 	}
 
-	logger.Println("Connected to MariaDB, monitoring replication status...")
+	logger.Info("connected, monitoring replication status", "event", "connected")
 
 	tracker := &LagTracker{
 		LastCheck: time.Now(),
 	}
 
+	shutdown := func() {
+		logger.Info("shutting down",
+			"event", "shutdown",
+			"errno", tracker.CurrentErrorCode,
+			"seconds_behind", tracker.LastSecondsBehind,
+			"backoff_seconds", tracker.BackoffSeconds)
+		// Clean up this target's metrics/health entry from here, not from whoever
+		// cancelled ctx: that's the only way to guarantee we've stopped touching
+		// recordStatus/recordSkip/etc. before the series disappears. Harmless on a
+		// plain process shutdown (nothing left to read them); load-bearing on a
+		// SIGHUP removal, where the process keeps running and a late write would
+		// resurrect a stale series for a target that's no longer configured.
+		removeTargetMetrics(target.Name)
+	}
+
 	for {
+		if ctx.Err() != nil {
+			shutdown()
+			return
+		}
+
 		for {
+			cfg := cfgAtomic.Load()
+			levelVar.Set(parseLogLevel(cfg.LogLevel))
+
 			status, err := getSlaveStatus(db)
 			if err != nil {
-				logger.Printf("Error checking replication: %v", err)
+				logger.Error("error checking replication", "event", "poll_error", "error", err)
+				recordDown(target.Name)
 				// Try to reconnect on connection errors
 				if err := db.Ping(); err != nil {
-					logger.Printf("Connection lost, reconnecting...")
+					logger.Warn("connection lost, reconnecting", "event", "connection_lost")
+				}
+				if !sleepCtx(ctx, 1*time.Second) {
+					shutdown()
+					return
 				}
-				time.Sleep(1 * time.Second)
 				break
 			}
 
-			// Calculate lag indicator
-			indicator := calculateLagIndicator(tracker, status)
+			// Calculate lag indicator, using GTID sequence deltas when in GTID mode
+			var indicator string
+			if effectiveReplicationMode(cfg, status) == "gtid" {
+				indicator = calculateGtidLagIndicator(tracker, status)
+			} else {
+				indicator = calculateLagIndicator(tracker, status, cfg)
+			}
+			recordStatus(target.Name, tracker, status)
+			if status.Errno != 0 {
+				recordReplicationError(target.Name, status.Errno)
+			}
 
 			// Check for position mismatch (Exec > Read indicates replication interruption)
 			if status.ExecMasterLogPos > status.ReadMasterLogPos && status.ExecMasterLogPos > 0 {
-				logger.Printf("errno=%d %s action=reset_position backoff=%ds (Exec_Master_Log_Pos > Read_Master_Log_Pos)",
-					status.Errno, indicator, tracker.BackoffSeconds)
+				logger.Info("exec position ahead of read position",
+					"event", "reset_position", "errno", status.Errno, "lag", indicator,
+					"read_pos", status.ReadMasterLogPos, "exec_pos", status.ExecMasterLogPos,
+					"backoff_seconds", tracker.BackoffSeconds)
 				// Reset backoff when taking action
 				tracker.ZeroErrCount = 0
 				tracker.BackoffSeconds = 0
-				if err := resetMasterLogPos(db, cfg); err != nil {
-					logger.Printf("Failed to reset master log position: %v", err)
+				if err := resetMasterLogPos(db, cfg, tracker, status, logger); err != nil {
+					if guardrail, ok := err.(*GuardrailError); ok {
+						logger.Warn("action blocked by guardrail",
+							"event", "blocked", "reason", guardrail.Reason, "action", "reset_pos", "errno", status.Errno)
+						runOnBlockCommand(cfg, target, guardrail.Reason, logger)
+						break
+					}
+					logger.Error("failed to reset master log position", "event", "reset_failed", "error", err)
 					if err := db.Ping(); err != nil {
-						logger.Printf("Connection lost, will reconnect")
+						logger.Warn("connection lost, will reconnect", "event", "connection_lost")
 					}
 					break
 				}
-				logger.Printf("Master log position reset to %d", cfg.MasterLogPos)
+				if effectiveReplicationMode(cfg, status) == "gtid" {
+					logger.Info("replication position reset via gtid_slave_pos", "event", "reset_complete")
+				} else {
+					logger.Info("master log position reset", "event", "reset_complete", "exec_pos", cfg.MasterLogPos)
+				}
+				recordPositionReset(target.Name)
 				// Continue to next iteration
 				continue
 			}
 
-			var action string
-			if status.Errno == 1062 {
-				action = "action=skip"
-				// Reset backoff when taking action
-				tracker.ZeroErrCount = 0
-				tracker.BackoffSeconds = 0
-
-				// Track error sequence
-				if tracker.CurrentErrorCode != 1062 {
-					// New error type - log if previous sequence exists
-					if tracker.ErrorCount > 1 {
-						logger.Printf("Fixed %d more errno=%d problems", tracker.ErrorCount-1, tracker.CurrentErrorCode)
-					}
-					// Start new sequence
-					tracker.CurrentErrorCode = 1062
-					tracker.ErrorCount = 1
-					tracker.FirstErrorTime = time.Now()
-					logger.Printf("errno=%d %s %s backoff=%ds", status.Errno, indicator, action, tracker.BackoffSeconds)
-				} else {
-					// Same error continues
-					tracker.ErrorCount++
+			if status.Errno != 0 {
+				rule := matchRule(cfg.Rules, status)
+				action := cfg.DefaultAction
+				if rule != nil {
+					action = rule.Action
 				}
 
-				if err := skipReplicationError(db); err != nil {
-					logger.Printf("errno=%d %s %s backoff=%ds error=%v", status.Errno, indicator, action, tracker.BackoffSeconds, err)
-					if err := db.Ping(); err != nil {
-						logger.Printf("Connection lost, will reconnect")
+				if action == "none" {
+					logger.Info("error detected, no action configured",
+						"event", "action_none", "errno", status.Errno, "lag_bytes", tracker.LastReadPos-tracker.LastExecPos,
+						"seconds_behind", status.SecondsBehindMaster, "backoff_seconds", tracker.BackoffSeconds)
+					tracker.ZeroErrCount = 0
+					if tracker.BackoffSeconds > 0 {
+						logger.Info("error detected, resetting backoff", "event", "backoff_reset", "errno", status.Errno)
+						tracker.BackoffSeconds = 0
 					}
 					break
 				}
-				// No sleep - loop immediately like bash script
-			} else if status.Errno == 1942 {
-				action = "action=optimize"
+
 				// Reset backoff when taking action
 				tracker.ZeroErrCount = 0
 				tracker.BackoffSeconds = 0
 
-				// Track error sequence
-				if tracker.CurrentErrorCode != 1942 {
-					// New error type - log if previous sequence exists
+				// Track error sequence, generalized across any errno rather than 1062/1942 only
+				if tracker.CurrentErrorCode != status.Errno {
 					if tracker.ErrorCount > 1 {
-						logger.Printf("Fixed %d more errno=%d problems", tracker.ErrorCount-1, tracker.CurrentErrorCode)
+						logger.Info("fixed repeated errors",
+							"event", "error_sequence_fixed", "errno", tracker.CurrentErrorCode, "count", tracker.ErrorCount-1)
 					}
-					// Start new sequence
-					tracker.CurrentErrorCode = 1942
+					tracker.CurrentErrorCode = status.Errno
 					tracker.ErrorCount = 1
 					tracker.FirstErrorTime = time.Now()
-					logger.Printf("errno=%d %s %s backoff=%ds", status.Errno, indicator, action, tracker.BackoffSeconds)
+					logger.Info("error detected, dispatching action",
+						"event", "action", "errno", status.Errno, "action", action,
+						"read_pos", status.ReadMasterLogPos, "exec_pos", status.ExecMasterLogPos,
+						"backoff_seconds", tracker.BackoffSeconds)
 				} else {
-					// Same error continues
 					tracker.ErrorCount++
 				}
 
-				if err := optimizeReplication(db, cfg); err != nil {
-					logger.Printf("errno=%d %s %s backoff=%ds error=%v", status.Errno, indicator, action, tracker.BackoffSeconds, err)
+				var err error
+				if rule != nil {
+					err = dispatchRule(db, cfg, target, tracker, status, rule, logger)
+				} else {
+					err = executeAction(db, cfg, tracker, status, action, logger)
+				}
+				if err != nil {
+					if guardrail, ok := err.(*GuardrailError); ok {
+						logger.Warn("action blocked by guardrail",
+							"event", "blocked", "reason", guardrail.Reason, "action", action, "errno", status.Errno)
+						runOnBlockCommand(cfg, target, guardrail.Reason, logger)
+						break
+					}
+					logger.Error("action failed",
+						"event", "action_failed", "errno", status.Errno, "action", action,
+						"backoff_seconds", tracker.BackoffSeconds, "error", err)
 					if err := db.Ping(); err != nil {
-						logger.Printf("Connection lost, will reconnect")
+						logger.Warn("connection lost, will reconnect", "event", "connection_lost")
 					}
 					break
 				}
-				// No sleep - loop immediately
+
+				switch action {
+				case "skip":
+					recordSkip(target.Name)
+				case "optimize":
+					recordOptimize(target.Name)
+				case "reset_pos":
+					recordPositionReset(target.Name)
+				}
+				// No backoff sleep - re-poll after the 25ms pause below, matching skip/optimize's original fast-retry behavior
 			} else {
 				// Check if we just finished an error sequence
-				if status.Errno == 0 && tracker.CurrentErrorCode != 0 {
+				if tracker.CurrentErrorCode != 0 {
 					if tracker.ErrorCount > 1 {
 						duration := time.Since(tracker.FirstErrorTime)
-						logger.Printf("Fixed %d more errno=%d problems (took %v) %s", tracker.ErrorCount-1, tracker.CurrentErrorCode, duration.Round(time.Second), indicator)
+						logger.Info("fixed repeated errors",
+							"event", "error_sequence_fixed", "errno", tracker.CurrentErrorCode,
+							"count", tracker.ErrorCount-1, "duration", duration.Round(time.Second).String())
 					}
 					// Reset error tracking
 					tracker.CurrentErrorCode = 0
 					tracker.ErrorCount = 0
 				}
 
-				// Only log if there's an error (errno != 0)
-				if status.Errno != 0 {
-					logger.Printf("errno=%d %s action=none backoff=%ds", status.Errno, indicator, tracker.BackoffSeconds)
-				}
+				// Backoff logic: only when caught up OR not falling further behind
+				isCaughtUp := status.SecondsBehindMaster == 0
+				isProgressing := tracker.LastSecondsBehind > 0 && status.SecondsBehindMaster <= tracker.LastSecondsBehind
+
+				if isCaughtUp || isProgressing {
+					tracker.ZeroErrCount++
+					if tracker.ZeroErrCount >= cfg.BackoffSuccessCount {
+						// Increase backoff up to configured max
+						tracker.BackoffSeconds++
+						if tracker.BackoffSeconds > cfg.MaxBackoffSeconds {
+							tracker.BackoffSeconds = cfg.MaxBackoffSeconds
+						}
+						// Only log at intervals (1s, 5s, 10s, 15s, etc.) or when reaching max
+						if tracker.BackoffSeconds == 1 || tracker.BackoffSeconds%5 == 0 || tracker.BackoffSeconds == cfg.MaxBackoffSeconds {
+							logger.Info("no errors detected, backed off",
+								"event", "backoff_increase", "backoff_seconds", tracker.BackoffSeconds)
+						}
+					}
 
-				// Backoff logic: only when errno=0 AND (caught up OR not falling further behind)
-				if status.Errno == 0 {
-					// Check if we're actually caught up or making progress
-					isCaughtUp := status.SecondsBehindMaster == 0
-					isProgressing := tracker.LastSecondsBehind > 0 && status.SecondsBehindMaster <= tracker.LastSecondsBehind
-
-					if isCaughtUp || isProgressing {
-						tracker.ZeroErrCount++
-						if tracker.ZeroErrCount >= cfg.BackoffSuccessCount {
-							// Increase backoff up to configured max
-							tracker.BackoffSeconds++
-							if tracker.BackoffSeconds > cfg.MaxBackoffSeconds {
-								tracker.BackoffSeconds = cfg.MaxBackoffSeconds
-							}
-							// Only log at intervals (1s, 5s, 10s, 15s, etc.) or when reaching max
-							if tracker.BackoffSeconds == 1 || tracker.BackoffSeconds%5 == 0 || tracker.BackoffSeconds == cfg.MaxBackoffSeconds {
-								logger.Printf("No errors detected, backed off to %ds", tracker.BackoffSeconds)
-							}
+					// Widen the poll interval proportionally to the ETA, even before
+					// BackoffSuccessCount is reached, so a slave steadily catching up from
+					// far behind doesn't get hammered with SHOW SLAVE STATUS.
+					if tracker.ETASeconds > cfg.EtaBackoffThresholdSeconds {
+						etaBackoff := int(tracker.ETASeconds / cfg.EtaBackoffThresholdSeconds)
+						if etaBackoff > cfg.MaxBackoffSeconds {
+							etaBackoff = cfg.MaxBackoffSeconds
+						}
+						if etaBackoff > tracker.BackoffSeconds {
+							tracker.BackoffSeconds = etaBackoff
+							logger.Info("eta exceeds threshold, widening poll interval",
+								"event", "eta_backoff_widen", "backoff_seconds", tracker.BackoffSeconds)
 						}
-					} else {
-						// Falling behind, reset counter but keep current backoff
-						tracker.ZeroErrCount = 0
 					}
-					tracker.LastSecondsBehind = status.SecondsBehindMaster
 				} else {
-					// Reset backoff on any error
+					// Falling behind, reset counter but keep current backoff
 					tracker.ZeroErrCount = 0
-					if tracker.BackoffSeconds > 0 {
-						logger.Printf("Error detected, resetting backoff")
-						tracker.BackoffSeconds = 0
-					}
 				}
+				tracker.LastSecondsBehind = status.SecondsBehindMaster
 				break
 			}
-			time.Sleep(25 * time.Millisecond)
+			if !sleepCtx(ctx, 25*time.Millisecond) {
+				shutdown()
+				return
+			}
 		}
 		// Apply backoff if in backoff state, otherwise use default
+		interval := 500 * time.Millisecond
 		if tracker.BackoffSeconds > 0 {
-			time.Sleep(time.Duration(tracker.BackoffSeconds) * time.Second)
-		} else {
-			time.Sleep(500 * time.Millisecond)
+			interval = time.Duration(tracker.BackoffSeconds) * time.Second
+		}
+		if !sleepCtx(ctx, interval) {
+			shutdown()
+			return
 		}
 	}
 }
 
-func calculateLagIndicator(tracker *LagTracker, status *SlaveStatus) string {
+// sleepCtx sleeps for d, returning false early if ctx is cancelled first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// calculateLagIndicator updates tracker's instantaneous rates and EWMAs from the latest
+// status, and renders a human-readable lag summary including an ETA-to-caught-up once the
+// EWMAs are warmed up.
+func calculateLagIndicator(tracker *LagTracker, status *SlaveStatus, cfg *GlobalConfig) string {
 	now := time.Now()
 	elapsed := now.Sub(tracker.LastCheck).Seconds()
 
@@ -346,7 +602,7 @@ func calculateLagIndicator(tracker *LagTracker, status *SlaveStatus) string {
 		return fmt.Sprintf("lag: %d bytes", currentLag)
 	}
 
-	// Calculate rates (bytes per second)
+	// Calculate instantaneous rates (bytes per second)
 	readRate := float64(status.ReadMasterLogPos-tracker.LastReadPos) / elapsed
 	execRate := float64(status.ExecMasterLogPos-tracker.LastExecPos) / elapsed
 
@@ -354,25 +610,144 @@ func calculateLagIndicator(tracker *LagTracker, status *SlaveStatus) string {
 	tracker.LastReadPos = status.ReadMasterLogPos
 	tracker.LastExecPos = status.ExecMasterLogPos
 	tracker.LastCheck = now
+	tracker.ReadRateBps = readRate
+	tracker.ExecRateBps = execRate
+
+	// Fold the instantaneous rates into EWMAs so the indicator stays stable under bursty
+	// binlog traffic. alpha is derived from the configured half-life and how long it's
+	// actually been since the last sample, so irregular polling intervals behave correctly.
+	halfLife := cfg.EwmaHalfLifeSeconds
+	if halfLife <= 0 {
+		halfLife = 60
+	}
+	alpha := 1 - math.Exp(-elapsed/halfLife)
+	if tracker.ReadRateEWMA == 0 && tracker.ExecRateEWMA == 0 {
+		tracker.ReadRateEWMA = readRate
+		tracker.ExecRateEWMA = execRate
+	} else {
+		tracker.ReadRateEWMA = alpha*readRate + (1-alpha)*tracker.ReadRateEWMA
+		tracker.ExecRateEWMA = alpha*execRate + (1-alpha)*tracker.ExecRateEWMA
+	}
 
 	// Determine indicator
 	var indicator string
+	const epsilon = 1.0 // bytes/sec floor to avoid dividing by ~0
 	if currentLag == 0 {
 		indicator = "✓ (caught up)"
-	} else if execRate > readRate {
+		tracker.ETASeconds = 0
+	} else if tracker.ExecRateEWMA > tracker.ReadRateEWMA {
 		// We're catching up
-		indicator = fmt.Sprintf("↑ (catching up, lag: %d bytes)", currentLag)
-	} else if execRate < readRate {
+		catchUpRate := tracker.ExecRateEWMA - tracker.ReadRateEWMA
+		if catchUpRate < epsilon {
+			catchUpRate = epsilon
+		}
+		tracker.ETASeconds = float64(currentLag) / catchUpRate
+		indicator = fmt.Sprintf("↑ (catching up, lag: %d bytes, eta: %s)", currentLag, formatETA(tracker.ETASeconds))
+	} else if tracker.ExecRateEWMA < tracker.ReadRateEWMA {
 		// We're falling behind
+		tracker.ETASeconds = 0
 		indicator = fmt.Sprintf("↓ (falling behind, lag: %d bytes)", currentLag)
 	} else {
 		// Rates are equal
+		tracker.ETASeconds = 0
 		indicator = fmt.Sprintf("→ (stable, lag: %d bytes)", currentLag)
 	}
 
 	return indicator
 }
 
+func formatETA(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+// effectiveReplicationMode resolves cfg.ReplicationMode to a concrete "filepos" or "gtid"
+// choice. In "auto" mode, it follows whatever Using_Gtid reports for the current target.
+func effectiveReplicationMode(cfg *GlobalConfig, status *SlaveStatus) string {
+	switch cfg.ReplicationMode {
+	case "gtid":
+		return "gtid"
+	case "filepos":
+		return "filepos"
+	default: // "auto"
+		if status.UsingGtid != "" && status.UsingGtid != "No" {
+			return "gtid"
+		}
+		return "filepos"
+	}
+}
+
+// parseGTIDSet parses a MariaDB GTID set string ("domain-server-seq[,domain-server-seq...]")
+// into a map of domain ID to sequence number, keeping only the highest sequence per domain.
+func parseGTIDSet(s string) map[uint64]uint64 {
+	result := make(map[uint64]uint64)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, "-")
+		if len(fields) != 3 {
+			continue
+		}
+		domain, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		seq, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		if seq > result[domain] {
+			result[domain] = seq
+		}
+	}
+	return result
+}
+
+// gtidSetRegex matches a MariaDB GTID set: one or more comma-separated
+// "domain-server-seq" triples. resetMasterLogPos uses it to validate a GTID string
+// before interpolating it into SQL, since unlike the filepos branch's integer
+// master_log_pos, recoveryGtid is a string that could otherwise carry arbitrary SQL.
+var gtidSetRegex = regexp.MustCompile(`^\d+-\d+-\d+(,\d+-\d+-\d+)*$`)
+
+// calculateGtidLagIndicator computes the per-domain gap between Gtid_IO_Pos and
+// Gtid_Slave_Pos, stores it on tracker, and renders a human-readable summary.
+func calculateGtidLagIndicator(tracker *LagTracker, status *SlaveStatus) string {
+	ioSet := parseGTIDSet(status.GtidIOPos)
+	slaveSet := parseGTIDSet(status.GtidSlavePos)
+
+	lag := make(map[uint64]int64, len(ioSet))
+	var total int64
+	for domain, ioSeq := range ioSet {
+		delta := int64(ioSeq) - int64(slaveSet[domain])
+		if delta < 0 {
+			delta = 0
+		}
+		lag[domain] = delta
+		total += delta
+	}
+	tracker.GtidDomainLag = lag
+
+	if total == 0 {
+		return "✓ (caught up, gtid)"
+	}
+
+	domains := make([]uint64, 0, len(lag))
+	for domain := range lag {
+		domains = append(domains, domain)
+	}
+	sort.Slice(domains, func(i, j int) bool { return domains[i] < domains[j] })
+
+	parts := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		if lag[domain] == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("domain %d: %d", domain, lag[domain]))
+	}
+	return fmt.Sprintf("⟳ (gtid lag, %s)", strings.Join(parts, ", "))
+}
+
 func getSlaveStatus(db *sql.DB) (*SlaveStatus, error) {
 	// Use raw query and parse only what we need - much faster than scanning all columns
 	rows, err := db.Query("SHOW SLAVE STATUS")
@@ -391,6 +766,10 @@ func getSlaveStatus(db *sql.DB) (*SlaveStatus, error) {
 	readPosIdx := -1
 	execPosIdx := -1
 	secondsBehindIdx := -1
+	gtidIOPosIdx := -1
+	gtidSlavePosIdx := -1
+	usingGtidIdx := -1
+	errorTextIdx := -1
 	for i, col := range columns {
 		switch col {
 		case "Last_SQL_Errno":
@@ -401,6 +780,14 @@ func getSlaveStatus(db *sql.DB) (*SlaveStatus, error) {
 			execPosIdx = i
 		case "Seconds_Behind_Master":
 			secondsBehindIdx = i
+		case "Gtid_IO_Pos":
+			gtidIOPosIdx = i
+		case "Gtid_Slave_Pos":
+			gtidSlavePosIdx = i
+		case "Using_Gtid":
+			usingGtidIdx = i
+		case "Last_SQL_Error":
+			errorTextIdx = i
 		}
 	}
 
@@ -415,9 +802,12 @@ func getSlaveStatus(db *sql.DB) (*SlaveStatus, error) {
 	// Create minimal scan targets - only allocate what we need
 	values := make([]interface{}, len(columns))
 	for i := range values {
-		if i == errnoIdx || i == readPosIdx || i == execPosIdx || i == secondsBehindIdx {
+		switch i {
+		case errnoIdx, readPosIdx, execPosIdx, secondsBehindIdx:
 			values[i] = new(sql.NullInt64)
-		} else {
+		case gtidIOPosIdx, gtidSlavePosIdx, usingGtidIdx, errorTextIdx:
+			values[i] = new(sql.NullString)
+		default:
 			values[i] = new(sql.RawBytes) // RawBytes is more efficient for unused columns
 		}
 	}
@@ -450,26 +840,87 @@ func getSlaveStatus(db *sql.DB) (*SlaveStatus, error) {
 		}
 	}
 
+	if gtidIOPosIdx != -1 {
+		if v, ok := values[gtidIOPosIdx].(*sql.NullString); ok && v.Valid {
+			status.GtidIOPos = v.String
+		}
+	}
+
+	if gtidSlavePosIdx != -1 {
+		if v, ok := values[gtidSlavePosIdx].(*sql.NullString); ok && v.Valid {
+			status.GtidSlavePos = v.String
+		}
+	}
+
+	if usingGtidIdx != -1 {
+		if v, ok := values[usingGtidIdx].(*sql.NullString); ok && v.Valid {
+			status.UsingGtid = v.String
+		}
+	}
+
+	if errorTextIdx != -1 {
+		if v, ok := values[errorTextIdx].(*sql.NullString); ok && v.Valid {
+			status.ErrorText = v.String
+		}
+	}
+
 	return status, nil
 }
 
-func skipReplicationError(db *sql.DB) error {
+func skipReplicationError(db *sql.DB, cfg *GlobalConfig, tracker *LagTracker, status *SlaveStatus, logger *slog.Logger) error {
+	if err := guardMutatingAction(cfg, tracker, status, "skip", time.Now()); err != nil {
+		return err
+	}
+
 	// Execute all commands in one round-trip for speed
-	_, err := db.Exec("STOP SLAVE; SET global sql_slave_skip_counter = 1; START SLAVE")
+	query := "STOP SLAVE; SET global sql_slave_skip_counter = 1; START SLAVE"
+	if cfg.DryRun {
+		logger.Info("dry_run: would execute", "event", "dry_run", "sql", query)
+		return nil
+	}
+	_, err := db.Exec(query)
 	return err
 }
 
-func resetMasterLogPos(db *sql.DB, cfg *GlobalConfig) error {
+func resetMasterLogPos(db *sql.DB, cfg *GlobalConfig, tracker *LagTracker, status *SlaveStatus, logger *slog.Logger) error {
+	if err := guardMutatingAction(cfg, tracker, status, "reset_pos", time.Now()); err != nil {
+		return err
+	}
+
 	// Reset master log position when Exec_Master_Log_Pos > Read_Master_Log_Pos
-	query := fmt.Sprintf("STOP SLAVE; CHANGE MASTER TO master_log_pos=%d; START SLAVE", cfg.MasterLogPos)
+	var query string
+	if effectiveReplicationMode(cfg, status) == "gtid" {
+		recoveryGtid := cfg.RecoveryGtid
+		if recoveryGtid == "" || recoveryGtid == "current" {
+			recoveryGtid = status.GtidIOPos
+		}
+		if !gtidSetRegex.MatchString(recoveryGtid) {
+			return fmt.Errorf("recovery gtid %q is not a valid GTID set, refusing to reset position", recoveryGtid)
+		}
+		query = fmt.Sprintf("STOP SLAVE; SET GLOBAL gtid_slave_pos='%s'; CHANGE MASTER TO master_use_gtid=slave_pos; START SLAVE", recoveryGtid)
+	} else {
+		query = fmt.Sprintf("STOP SLAVE; CHANGE MASTER TO master_log_pos=%d; START SLAVE", cfg.MasterLogPos)
+	}
+	if cfg.DryRun {
+		logger.Info("dry_run: would execute", "event", "dry_run", "sql", query)
+		return nil
+	}
 	_, err := db.Exec(query)
 	return err
 }
 
-func optimizeReplication(db *sql.DB, cfg *GlobalConfig) error {
+func optimizeReplication(db *sql.DB, cfg *GlobalConfig, tracker *LagTracker, status *SlaveStatus, logger *slog.Logger) error {
+	if err := guardMutatingAction(cfg, tracker, status, "optimize", time.Now()); err != nil {
+		return err
+	}
+
 	// Execute all commands in one round-trip for speed
 	query := fmt.Sprintf("STOP SLAVE; SET GLOBAL slave_parallel_max_queued = %d; SET GLOBAL slave_parallel_threads = %d; SET GLOBAL slave_domain_parallel_threads = %d; SET GLOBAL slave_parallel_mode = 'optimistic'; START SLAVE;",
 		cfg.SlaveParallelMaxQueued, cfg.SlaveParallelThreads, cfg.SlaveDomainParallelThreads)
+	if cfg.DryRun {
+		logger.Info("dry_run: would execute", "event", "dry_run", "sql", query)
+		return nil
+	}
 	_, err := db.Exec(query)
 	return err
 }
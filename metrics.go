@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus metrics, one series per monitored target (labeled "target").
+var (
+	metricSecondsBehindMaster = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "maria_repl_seconds_behind_master",
+		Help: "Seconds_Behind_Master as reported by SHOW SLAVE STATUS.",
+	}, []string{"target"})
+
+	metricReadExecByteLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "maria_repl_read_exec_byte_lag",
+		Help: "Read_Master_Log_Pos minus Exec_Master_Log_Pos, in bytes.",
+	}, []string{"target"})
+
+	metricReadRateBps = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "maria_repl_read_rate_bps",
+		Help: "Observed rate of Read_Master_Log_Pos advancement, bytes/sec.",
+	}, []string{"target"})
+
+	metricExecRateBps = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "maria_repl_exec_rate_bps",
+		Help: "Observed rate of Exec_Master_Log_Pos advancement, bytes/sec.",
+	}, []string{"target"})
+
+	metricBackoffSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "maria_repl_backoff_seconds",
+		Help: "Current poll backoff interval for the target.",
+	}, []string{"target"})
+
+	metricSlaveUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "maria_repl_slave_up",
+		Help: "1 if the last SHOW SLAVE STATUS poll succeeded, 0 otherwise.",
+	}, []string{"target"})
+
+	metricReplicationErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "maria_repl_replication_errors_total",
+		Help: "Count of Last_SQL_Errno observations, by errno.",
+	}, []string{"target", "errno"})
+
+	metricSkipsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "maria_repl_skips_total",
+		Help: "Count of sql_slave_skip_counter actions taken.",
+	}, []string{"target"})
+
+	metricOptimizeTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "maria_repl_optimize_total",
+		Help: "Count of optimistic-parallel-replication tuning actions taken.",
+	}, []string{"target"})
+
+	metricPositionResetsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "maria_repl_position_resets_total",
+		Help: "Count of master log position resets taken.",
+	}, []string{"target"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		metricSecondsBehindMaster,
+		metricReadExecByteLag,
+		metricReadRateBps,
+		metricExecRateBps,
+		metricBackoffSeconds,
+		metricSlaveUp,
+		metricReplicationErrorsTotal,
+		metricSkipsTotal,
+		metricOptimizeTotal,
+		metricPositionResetsTotal,
+	)
+}
+
+// TargetHealth is the JSON shape served from /health, one entry per monitored target.
+type TargetHealth struct {
+	Target              string    `json:"target"`
+	Up                  bool      `json:"up"`
+	Errno               int       `json:"errno"`
+	ReadMasterLogPos    int64     `json:"read_master_log_pos"`
+	ExecMasterLogPos    int64     `json:"exec_master_log_pos"`
+	SecondsBehindMaster int64     `json:"seconds_behind_master"`
+	BackoffSeconds      int       `json:"backoff_seconds"`
+	LastCheck           time.Time `json:"last_check"`
+}
+
+var (
+	healthMu    sync.RWMutex
+	healthState = map[string]TargetHealth{}
+)
+
+// recordStatus updates both the Prometheus gauges and the /health snapshot for target
+// after a successful SHOW SLAVE STATUS poll.
+func recordStatus(target string, tracker *LagTracker, status *SlaveStatus) {
+	lag := status.ReadMasterLogPos - status.ExecMasterLogPos
+
+	metricSecondsBehindMaster.WithLabelValues(target).Set(float64(status.SecondsBehindMaster))
+	metricReadExecByteLag.WithLabelValues(target).Set(float64(lag))
+	metricReadRateBps.WithLabelValues(target).Set(tracker.ReadRateBps)
+	metricExecRateBps.WithLabelValues(target).Set(tracker.ExecRateBps)
+	metricBackoffSeconds.WithLabelValues(target).Set(float64(tracker.BackoffSeconds))
+	metricSlaveUp.WithLabelValues(target).Set(1)
+
+	healthMu.Lock()
+	healthState[target] = TargetHealth{
+		Target:              target,
+		Up:                  true,
+		Errno:               status.Errno,
+		ReadMasterLogPos:    status.ReadMasterLogPos,
+		ExecMasterLogPos:    status.ExecMasterLogPos,
+		SecondsBehindMaster: status.SecondsBehindMaster,
+		BackoffSeconds:      tracker.BackoffSeconds,
+		LastCheck:           time.Now(),
+	}
+	healthMu.Unlock()
+}
+
+// recordDown marks target as unreachable in both the gauge and the /health snapshot.
+func recordDown(target string) {
+	metricSlaveUp.WithLabelValues(target).Set(0)
+
+	healthMu.Lock()
+	h := healthState[target]
+	h.Target = target
+	h.Up = false
+	h.LastCheck = time.Now()
+	healthState[target] = h
+	healthMu.Unlock()
+}
+
+func recordReplicationError(target string, errno int) {
+	metricReplicationErrorsTotal.WithLabelValues(target, strconv.Itoa(errno)).Inc()
+}
+
+func recordSkip(target string) {
+	metricSkipsTotal.WithLabelValues(target).Inc()
+}
+
+func recordOptimize(target string) {
+	metricOptimizeTotal.WithLabelValues(target).Inc()
+}
+
+func recordPositionReset(target string) {
+	metricPositionResetsTotal.WithLabelValues(target).Inc()
+}
+
+// removeTargetMetrics drops target's entry from /health and all its Prometheus series, so a
+// target removed from config on SIGHUP reload stops reporting stale last-known values.
+func removeTargetMetrics(target string) {
+	healthMu.Lock()
+	delete(healthState, target)
+	healthMu.Unlock()
+
+	metricSecondsBehindMaster.DeleteLabelValues(target)
+	metricReadExecByteLag.DeleteLabelValues(target)
+	metricReadRateBps.DeleteLabelValues(target)
+	metricExecRateBps.DeleteLabelValues(target)
+	metricBackoffSeconds.DeleteLabelValues(target)
+	metricSlaveUp.DeleteLabelValues(target)
+	metricSkipsTotal.DeleteLabelValues(target)
+	metricOptimizeTotal.DeleteLabelValues(target)
+	metricPositionResetsTotal.DeleteLabelValues(target)
+	metricReplicationErrorsTotal.DeletePartialMatch(prometheus.Labels{"target": target})
+}
+
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	healthMu.RLock()
+	snapshot := make([]TargetHealth, 0, len(healthState))
+	for _, h := range healthState {
+		snapshot = append(snapshot, h)
+	}
+	healthMu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+		log.Printf("Failed to encode /health response: %v", err)
+	}
+}
+
+// startMetricsServer serves /metrics (Prometheus) and /health (JSON) on addr. It runs in its
+// own goroutine; a failure to bind is logged but does not stop the monitors themselves.
+func startMetricsServer(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/health", healthHandler)
+
+	go func() {
+		log.Printf("Starting metrics server on %s", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Metrics server stopped: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// Rule is one [rule:<name>] section: a match predicate (errno, optional error text regex)
+// and an action to take when it fires, with optional consecutive-failure escalation and
+// a cooldown to keep the action from being re-fired on every poll.
+type Rule struct {
+	Name           string
+	Errno          int
+	ErrorTextRegex *regexp.Regexp
+	Action         string // skip|optimize|reset_pos|stop_slave|exec_sql|shell
+	SQL            string // template for action=exec_sql
+	Command        string // template for action=shell
+	MaxConsecutive int    // 0 means no escalation cap
+	Cooldown       time.Duration
+}
+
+// loadRules reads every [rule:<name>] section into an ordered list of Rules. Order is
+// preserved from the ini file, and matchRule takes the first rule that matches.
+func loadRules(cfg *ini.File) ([]Rule, error) {
+	var rules []Rule
+	for _, section := range cfg.Sections() {
+		if !strings.HasPrefix(section.Name(), "rule:") {
+			continue
+		}
+		name := strings.TrimPrefix(section.Name(), "rule:")
+
+		rule := Rule{
+			Name:           name,
+			Errno:          section.Key("errno").MustInt(0),
+			Action:         section.Key("action").String(),
+			SQL:            section.Key("sql").String(),
+			Command:        section.Key("command").String(),
+			MaxConsecutive: section.Key("max_consecutive").MustInt(0),
+		}
+
+		if pattern := section.Key("error_text_regex").String(); pattern != "" {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: invalid error_text_regex: %w", name, err)
+			}
+			rule.ErrorTextRegex = re
+		}
+
+		if cooldown := section.Key("cooldown").String(); cooldown != "" {
+			d, err := time.ParseDuration(cooldown)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: invalid cooldown: %w", name, err)
+			}
+			rule.Cooldown = d
+		}
+
+		switch rule.Action {
+		case "skip", "optimize", "reset_pos", "stop_slave":
+		case "exec_sql":
+			if rule.SQL == "" {
+				return nil, fmt.Errorf("rule %q: action=exec_sql requires sql", name)
+			}
+		case "shell":
+			if rule.Command == "" {
+				return nil, fmt.Errorf("rule %q: action=shell requires command", name)
+			}
+		default:
+			return nil, fmt.Errorf("rule %q: invalid action %q", name, rule.Action)
+		}
+
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// matchRule returns the first rule whose errno (and, if set, error_text_regex) matches
+// status, or nil if none do.
+func matchRule(rules []Rule, status *SlaveStatus) *Rule {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Errno != status.Errno {
+			continue
+		}
+		if rule.ErrorTextRegex != nil && !rule.ErrorTextRegex.MatchString(status.ErrorText) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// dispatchRule executes rule's action, honoring its cooldown and escalating to stop_slave
+// once tracker.ErrorCount exceeds rule.MaxConsecutive.
+func dispatchRule(db *sql.DB, cfg *GlobalConfig, target Target, tracker *LagTracker, status *SlaveStatus, rule *Rule, logger *slog.Logger) error {
+	if tracker.RuleLastFired == nil {
+		tracker.RuleLastFired = make(map[string]time.Time)
+	}
+	if rule.Cooldown > 0 {
+		if last, ok := tracker.RuleLastFired[rule.Name]; ok && time.Since(last) < rule.Cooldown {
+			return nil
+		}
+	}
+	tracker.RuleLastFired[rule.Name] = time.Now()
+
+	action, escalated := resolveRuleAction(rule, tracker)
+	if escalated {
+		logger.Warn("rule exceeded max_consecutive, escalating to stop_slave",
+			"event", "rule_escalated", "rule", rule.Name, "errno", status.Errno, "action", "stop_slave")
+	}
+
+	switch action {
+	case "exec_sql":
+		if err := guardMutatingAction(cfg, tracker, status, action, time.Now()); err != nil {
+			return err
+		}
+		query := renderTemplate(rule.SQL, target, status)
+		if cfg.DryRun {
+			logger.Info("dry_run: would execute", "event", "dry_run", "rule", rule.Name, "sql", query)
+			return nil
+		}
+		_, err := db.Exec(query)
+		return err
+	case "shell":
+		if err := guardMutatingAction(cfg, tracker, status, action, time.Now()); err != nil {
+			return err
+		}
+		command := renderTemplate(rule.Command, target, status)
+		if cfg.DryRun {
+			logger.Info("dry_run: would run", "event", "dry_run", "rule", rule.Name, "command", command)
+			return nil
+		}
+		cmd := exec.Command("sh", "-c", command)
+		return cmd.Run()
+	default:
+		return executeAction(db, cfg, tracker, status, action, logger)
+	}
+}
+
+// resolveRuleAction returns the action dispatchRule should take for rule, escalating to
+// stop_slave once tracker.ErrorCount exceeds rule.MaxConsecutive (0 means no escalation cap).
+func resolveRuleAction(rule *Rule, tracker *LagTracker) (action string, escalated bool) {
+	if rule.MaxConsecutive > 0 && tracker.ErrorCount > rule.MaxConsecutive {
+		return "stop_slave", true
+	}
+	return rule.Action, false
+}
+
+// executeAction runs one of the built-in actions that don't need a rule's template fields.
+func executeAction(db *sql.DB, cfg *GlobalConfig, tracker *LagTracker, status *SlaveStatus, action string, logger *slog.Logger) error {
+	switch action {
+	case "skip":
+		return skipReplicationError(db, cfg, tracker, status, logger)
+	case "optimize":
+		return optimizeReplication(db, cfg, tracker, status, logger)
+	case "reset_pos":
+		return resetMasterLogPos(db, cfg, tracker, status, logger)
+	case "stop_slave":
+		return stopSlave(db)
+	default:
+		return fmt.Errorf("unsupported action %q", action)
+	}
+}
+
+func stopSlave(db *sql.DB) error {
+	_, err := db.Exec("STOP SLAVE")
+	return err
+}
+
+// renderTemplate substitutes {{errno}}, {{target}}, {{read_pos}}, and {{exec_pos}} in a
+// rule's sql/command template.
+func renderTemplate(tmpl string, target Target, status *SlaveStatus) string {
+	replacer := strings.NewReplacer(
+		"{{errno}}", strconv.Itoa(status.Errno),
+		"{{target}}", target.Name,
+		"{{read_pos}}", strconv.FormatInt(status.ReadMasterLogPos, 10),
+		"{{exec_pos}}", strconv.FormatInt(status.ExecMasterLogPos, 10),
+	)
+	return replacer.Replace(tmpl)
+}
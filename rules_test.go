@@ -0,0 +1,101 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestMatchRule(t *testing.T) {
+	rules := []Rule{
+		{Name: "dup-key", Errno: 1062, Action: "skip"},
+		{Name: "dup-key-specific", Errno: 1062, ErrorTextRegex: regexp.MustCompile(`Duplicate entry 'x'`), Action: "reset_pos"},
+		{Name: "table-missing", Errno: 1146, Action: "optimize"},
+	}
+
+	tests := []struct {
+		name   string
+		status *SlaveStatus
+		want   string // Name of the expected rule, "" for no match
+	}{
+		{"matches by errno, first rule wins", &SlaveStatus{Errno: 1062, ErrorText: "anything"}, "dup-key"},
+		{"no rule for unlisted errno", &SlaveStatus{Errno: 9999}, ""},
+		{"regex-qualified rule still loses to an earlier unconditional match", &SlaveStatus{Errno: 1062, ErrorText: "Duplicate entry 'x'"}, "dup-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchRule(rules, tt.status)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Errorf("matchRule() = %q, want no match", got.Name)
+			case tt.want != "" && got == nil:
+				t.Errorf("matchRule() = nil, want %q", tt.want)
+			case tt.want != "" && got.Name != tt.want:
+				t.Errorf("matchRule() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRuleRegexMustMatch(t *testing.T) {
+	rules := []Rule{
+		{Name: "specific-text", Errno: 1062, ErrorTextRegex: regexp.MustCompile(`Duplicate entry 'x'`), Action: "skip"},
+	}
+
+	if got := matchRule(rules, &SlaveStatus{Errno: 1062, ErrorText: "Duplicate entry 'y'"}); got != nil {
+		t.Errorf("matchRule() = %q, want no match when error_text_regex doesn't match", got.Name)
+	}
+	if got := matchRule(rules, &SlaveStatus{Errno: 1062, ErrorText: "Duplicate entry 'x' for key PRIMARY"}); got == nil {
+		t.Error("matchRule() = nil, want a match when error_text_regex matches")
+	}
+}
+
+func TestResolveRuleAction(t *testing.T) {
+	tests := []struct {
+		name          string
+		rule          *Rule
+		errorCount    int
+		wantAction    string
+		wantEscalated bool
+	}{
+		{"no escalation cap configured", &Rule{Action: "skip", MaxConsecutive: 0}, 100, "skip", false},
+		{"under the cap", &Rule{Action: "skip", MaxConsecutive: 5}, 5, "skip", false},
+		{"over the cap escalates to stop_slave", &Rule{Action: "skip", MaxConsecutive: 5}, 6, "stop_slave", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &LagTracker{ErrorCount: tt.errorCount}
+			action, escalated := resolveRuleAction(tt.rule, tracker)
+			if action != tt.wantAction || escalated != tt.wantEscalated {
+				t.Errorf("resolveRuleAction() = (%q, %v), want (%q, %v)", action, escalated, tt.wantAction, tt.wantEscalated)
+			}
+		})
+	}
+}
+
+func TestDispatchRuleCooldownSkipsReExecution(t *testing.T) {
+	rule := &Rule{Name: "noisy", Errno: 1062, Action: "stop_slave", Cooldown: time.Minute}
+	tracker := &LagTracker{
+		RuleLastFired: map[string]time.Time{"noisy": time.Now()},
+	}
+
+	// db is intentionally nil: if the cooldown didn't short-circuit before touching it,
+	// this would panic.
+	err := dispatchRule(nil, &GlobalConfig{}, Target{Name: "t1"}, tracker, &SlaveStatus{Errno: 1062}, rule, nil)
+	if err != nil {
+		t.Errorf("dispatchRule() during cooldown = %v, want nil", err)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	target := Target{Name: "primary"}
+	status := &SlaveStatus{Errno: 1062, ReadMasterLogPos: 500, ExecMasterLogPos: 400}
+
+	got := renderTemplate("errno={{errno}} target={{target}} read={{read_pos}} exec={{exec_pos}}", target, status)
+	want := "errno=1062 target=primary read=500 exec=400"
+	if got != want {
+		t.Errorf("renderTemplate() = %q, want %q", got, want)
+	}
+}